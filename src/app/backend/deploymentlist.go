@@ -0,0 +1,205 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"log"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+// GetDeploymentList returns a list of all Deployments in the cluster, together with the
+// ReplicaSets they own, using the same ReplicaSet model as GetReplicaSetList so the dashboard
+// can render Replication Controllers and apps workloads side by side.
+func GetDeploymentList(client *client.Client) (*ReplicaSetList, error) {
+	log.Printf("Getting list of all deployments in the cluster")
+
+	listEverything := unversioned.ListOptions{
+		LabelSelector: unversioned.LabelSelector{labels.Everything()},
+		FieldSelector: unversioned.FieldSelector{fields.Everything()},
+	}
+
+	deployments, err := client.Extensions().Deployments(api.NamespaceAll).List(listEverything)
+	if err != nil {
+		return nil, err
+	}
+
+	replicaSets, err := client.Extensions().ReplicaSets(api.NamespaceAll).List(listEverything)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := client.Services(api.NamespaceAll).List(listEverything)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := client.Pods(api.NamespaceAll).List(listEverything)
+	if err != nil {
+		return nil, err
+	}
+
+	return getDeploymentList(deployments.Items, replicaSets.Items, services.Items, pods.Items), nil
+}
+
+// getDeploymentList builds one ReplicaSet row per Deployment (carrying its rolling-update
+// status) plus one row per extensions ReplicaSet (carrying the name of the Deployment that
+// owns it, if any), so the dashboard can show rollout history alongside the current state.
+func getDeploymentList(deployments []extensions.Deployment, replicaSets []extensions.ReplicaSet,
+	services []api.Service, pods []api.Pod) *ReplicaSetList {
+
+	list := &ReplicaSetList{ReplicaSets: make([]ReplicaSet, 0)}
+
+	for _, deployment := range deployments {
+		var containerNames []string
+		var containerImages []string
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			containerNames = append(containerNames, container.Name)
+			containerImages = append(containerImages, container.Image)
+		}
+
+		var internalEndpoints []Endpoint
+		var externalEndpoints []Endpoint
+		for _, service := range services {
+			if service.ObjectMeta.Namespace != deployment.ObjectMeta.Namespace ||
+				!isLabelSelectorMatching(service.Spec.Selector, deployment.Spec.Template.ObjectMeta.Labels) {
+				continue
+			}
+			internalEndpoints = append(internalEndpoints,
+				getInternalEndpoint(service.Name, service.Namespace, service.Spec.Ports))
+			for _, externalIP := range service.Status.LoadBalancer.Ingress {
+				externalEndpoints = append(externalEndpoints,
+					getExternalEndpoint(externalIP, service.Spec.Ports))
+			}
+		}
+
+		list.ReplicaSets = append(list.ReplicaSets, ReplicaSet{
+			Name:              deployment.ObjectMeta.Name,
+			Namespace:         deployment.ObjectMeta.Namespace,
+			Description:       deployment.Annotations[DescriptionAnnotationKey],
+			Labels:            deployment.ObjectMeta.Labels,
+			Selector:          flattenedSelector(deployment.Spec.Selector),
+			Kind:              DeploymentKind,
+			Pods:              getDeploymentPodInfo(&deployment, pods),
+			ContainerNames:    containerNames,
+			ContainerImages:   containerImages,
+			CreationTime:      deployment.ObjectMeta.CreationTimestamp,
+			InternalEndpoints: internalEndpoints,
+			ExternalEndpoints: externalEndpoints,
+			RollingUpdate: &RollingUpdateStatus{
+				UpdatedReplicas:     deployment.Status.UpdatedReplicas,
+				AvailableReplicas:   deployment.Status.AvailableReplicas,
+				UnavailableReplicas: deployment.Status.UnavailableReplicas,
+			},
+		})
+	}
+
+	for _, replicaSet := range replicaSets {
+		var containerNames []string
+		var containerImages []string
+		for _, container := range replicaSet.Spec.Template.Spec.Containers {
+			containerNames = append(containerNames, container.Name)
+			containerImages = append(containerImages, container.Image)
+		}
+
+		list.ReplicaSets = append(list.ReplicaSets, ReplicaSet{
+			Name:            replicaSet.ObjectMeta.Name,
+			Namespace:       replicaSet.ObjectMeta.Namespace,
+			Description:     replicaSet.Annotations[DescriptionAnnotationKey],
+			Labels:          replicaSet.ObjectMeta.Labels,
+			Selector:        flattenedSelector(replicaSet.Spec.Selector),
+			Kind:            ReplicaSetKind,
+			Deployment:      getOwningDeploymentName(&replicaSet, deployments),
+			Pods:            getExtensionsReplicaSetPodInfo(&replicaSet, pods),
+			ContainerNames:  containerNames,
+			ContainerImages: containerImages,
+			CreationTime:    replicaSet.ObjectMeta.CreationTimestamp,
+		})
+	}
+
+	return list
+}
+
+// flattenedSelector converts an unversioned.LabelSelector to a plain label map for display,
+// returning nil when the selector relies on matchExpressions that cannot be flattened.
+func flattenedSelector(selector *unversioned.LabelSelector) map[string]string {
+	if selector == nil || len(selector.MatchExpressions) > 0 {
+		return nil
+	}
+	return selector.MatchLabels
+}
+
+// getOwningDeploymentName finds the Deployment that owns the given ReplicaSet by matching the
+// Deployment's selector against the ReplicaSet's labels, the same way the pod-template-hash
+// controller associates the two: a ReplicaSet belongs to a Deployment when it carries the
+// Deployment's pod-template-hash label and its labels satisfy the Deployment's selector.
+func getOwningDeploymentName(replicaSet *extensions.ReplicaSet,
+	deployments []extensions.Deployment) string {
+
+	if _, ok := replicaSet.ObjectMeta.Labels[extensions.DefaultDeploymentUniqueLabelKey]; !ok {
+		return ""
+	}
+
+	for _, deployment := range deployments {
+		if deployment.ObjectMeta.Namespace == replicaSet.ObjectMeta.Namespace &&
+			isObjectSelectorMatching(deployment.Spec.Selector, replicaSet.ObjectMeta.Labels) {
+			return deployment.ObjectMeta.Name
+		}
+	}
+
+	return ""
+}
+
+// getDeploymentPodInfo aggregates pod counts for all pods matching a Deployment's selector,
+// i.e. pods belonging to any of its old or new ReplicaSets.
+func getDeploymentPodInfo(deployment *extensions.Deployment, pods []api.Pod) PodInfo {
+	result := PodInfo{
+		Current: deployment.Status.Replicas,
+		Desired: deployment.Spec.Replicas,
+	}
+
+	for _, pod := range pods {
+		if pod.ObjectMeta.Namespace == deployment.ObjectMeta.Namespace &&
+			isObjectSelectorMatching(deployment.Spec.Selector, pod.ObjectMeta.Labels) {
+			updatePodInfoForPhase(&result, &pod)
+			updatePodInfoForContainers(&result, &pod)
+		}
+	}
+
+	return result
+}
+
+// getExtensionsReplicaSetPodInfo aggregates pod counts for a single extensions ReplicaSet.
+func getExtensionsReplicaSetPodInfo(replicaSet *extensions.ReplicaSet, pods []api.Pod) PodInfo {
+	result := PodInfo{
+		Current: replicaSet.Status.Replicas,
+		Desired: replicaSet.Spec.Replicas,
+	}
+
+	for _, pod := range pods {
+		if pod.ObjectMeta.Namespace == replicaSet.ObjectMeta.Namespace &&
+			isObjectSelectorMatching(replicaSet.Spec.Selector, pod.ObjectMeta.Labels) {
+			updatePodInfoForPhase(&result, &pod)
+			updatePodInfoForContainers(&result, &pod)
+		}
+	}
+
+	return result
+}
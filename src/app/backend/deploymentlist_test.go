@@ -0,0 +1,116 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+func TestFlattenedSelector(t *testing.T) {
+	cases := []struct {
+		name     string
+		selector *unversioned.LabelSelector
+		want     map[string]string
+	}{
+		{name: "nil selector", selector: nil, want: nil},
+		{
+			name:     "matchLabels only",
+			selector: &unversioned.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			want:     map[string]string{"app": "redis"},
+		},
+		{
+			name: "matchExpressions cannot be flattened",
+			selector: &unversioned.LabelSelector{
+				MatchLabels: map[string]string{"app": "redis"},
+				MatchExpressions: []unversioned.LabelSelectorRequirement{
+					{Key: "tier", Operator: unversioned.LabelSelectorOpIn, Values: []string{"backend"}},
+				},
+			},
+			want: nil,
+		},
+	}
+
+	for _, c := range cases {
+		got := flattenedSelector(c.selector)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: flattenedSelector() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestGetOwningDeploymentName(t *testing.T) {
+	deployments := []extensions.Deployment{
+		{
+			ObjectMeta: api.ObjectMeta{Name: "redis", Namespace: "default"},
+			Spec: extensions.DeploymentSpec{
+				Selector: &unversioned.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			},
+		},
+	}
+
+	owned := extensions.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{
+			Namespace: "default",
+			Labels: map[string]string{
+				"app": "redis",
+				extensions.DefaultDeploymentUniqueLabelKey: "abcdef",
+			},
+		},
+	}
+	if got := getOwningDeploymentName(&owned, deployments); got != "redis" {
+		t.Errorf("getOwningDeploymentName() = %q, want %q", got, "redis")
+	}
+
+	noPodTemplateHash := extensions.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{Namespace: "default", Labels: map[string]string{"app": "redis"}},
+	}
+	if got := getOwningDeploymentName(&noPodTemplateHash, deployments); got != "" {
+		t.Errorf("getOwningDeploymentName() without pod-template-hash label = %q, want \"\"", got)
+	}
+
+	otherNamespace := extensions.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{
+			Namespace: "other",
+			Labels: map[string]string{
+				"app": "redis",
+				extensions.DefaultDeploymentUniqueLabelKey: "abcdef",
+			},
+		},
+	}
+	if got := getOwningDeploymentName(&otherNamespace, deployments); got != "" {
+		t.Errorf("getOwningDeploymentName() in a different namespace = %q, want \"\"", got)
+	}
+}
+
+func TestIsObjectSelectorMatching(t *testing.T) {
+	selector := &unversioned.LabelSelector{MatchLabels: map[string]string{"app": "redis"}}
+
+	if !isObjectSelectorMatching(selector, map[string]string{"app": "redis", "tier": "backend"}) {
+		t.Errorf("isObjectSelectorMatching() = false, want true for a superset of labels")
+	}
+
+	if isObjectSelectorMatching(selector, map[string]string{"app": "memcached"}) {
+		t.Errorf("isObjectSelectorMatching() = true, want false for a non-matching label")
+	}
+
+	if isObjectSelectorMatching(nil, map[string]string{"app": "redis"}) {
+		t.Errorf("isObjectSelectorMatching() with nil selector = true, want false")
+	}
+}
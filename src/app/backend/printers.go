@@ -0,0 +1,154 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+// Printer renders a ReplicaSetListResponse in a specific output format, the same role
+// kubectl's printers play for its `-o` flag. The HTTP handler serving the Replica Set list
+// dispatches to one of these so the same endpoint can back both the SPA and scripted
+// consumers.
+type Printer interface {
+	// ContentType is the HTTP Content-Type the handler should set before calling
+	// PrintReplicaSetList.
+	ContentType() string
+
+	PrintReplicaSetList(w io.Writer, list *ReplicaSetListResponse) error
+}
+
+// PrinterForOutput resolves the `output` query parameter to a Printer. tmpl is only used when
+// output is "template".
+func PrinterForOutput(output, tmpl string) (Printer, error) {
+	switch output {
+	case "", "json":
+		return jsonPrinter{}, nil
+	case "yaml":
+		return yamlPrinter{}, nil
+	case "wide":
+		return widePrinter{}, nil
+	case "template":
+		return newTemplatePrinter(tmpl)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", output)
+	}
+}
+
+// jsonPrinter renders the list as JSON, matching the endpoint's default representation.
+type jsonPrinter struct{}
+
+func (jsonPrinter) ContentType() string { return "application/json" }
+
+func (jsonPrinter) PrintReplicaSetList(w io.Writer, list *ReplicaSetListResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+// yamlPrinter renders the list as YAML, reusing the JSON struct tags the way kubectl's
+// YAMLPrinter does.
+type yamlPrinter struct{}
+
+func (yamlPrinter) ContentType() string { return "application/yaml" }
+
+func (yamlPrinter) PrintReplicaSetList(w io.Writer, list *ReplicaSetListResponse) error {
+	out, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// widePrinter renders a tab-aligned NAME/DESIRED/CURRENT/READY/AGE/CONTAINERS/IMAGES/SELECTOR
+// table, mirroring `kubectl get rc -o wide`.
+type widePrinter struct{}
+
+func (widePrinter) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (widePrinter) PrintReplicaSetList(w io.Writer, list *ReplicaSetListResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDESIRED\tCURRENT\tREADY\tAGE\tCONTAINERS\tIMAGES\tSELECTOR")
+
+	for _, replicaSet := range list.ReplicaSets {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			replicaSet.Name,
+			replicaSet.Pods.Desired,
+			replicaSet.Pods.Current,
+			readySummary(&replicaSet.Pods),
+			translateTimestampSince(replicaSet.CreationTime),
+			strings.Join(replicaSet.ContainerNames, ","),
+			strings.Join(replicaSet.ContainerImages, ","),
+			labelsToString(replicaSet.Selector))
+	}
+
+	return tw.Flush()
+}
+
+// readySummary reports running pods against desired replicas, e.g. "2/3".
+func readySummary(pods *PodInfo) string {
+	return fmt.Sprintf("%d/%d", pods.Running, pods.Desired)
+}
+
+// labelsToString renders a label map the way kubectl does, e.g. "app=foo,tier=backend".
+func labelsToString(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "<none>"
+	}
+
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// translateTimestampSince formats how long ago a timestamp was for the AGE column, as a Go
+// duration rounded to the second, e.g. "1h2m3s".
+func translateTimestampSince(timestamp unversioned.Time) string {
+	return time.Since(timestamp.Time).Round(time.Second).String()
+}
+
+// templatePrinter renders the list through a user-supplied Go template, as with
+// `kubectl get -o template --template=...`.
+type templatePrinter struct {
+	tmpl *template.Template
+}
+
+func newTemplatePrinter(tmpl string) (Printer, error) {
+	parsed, err := template.New("output").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return templatePrinter{tmpl: parsed}, nil
+}
+
+func (templatePrinter) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (p templatePrinter) PrintReplicaSetList(w io.Writer, list *ReplicaSetListResponse) error {
+	return p.tmpl.Execute(w, list)
+}
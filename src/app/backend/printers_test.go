@@ -0,0 +1,127 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrinterForOutput(t *testing.T) {
+	cases := []struct {
+		output      string
+		wantType    Printer
+		wantContent string
+	}{
+		{output: "", wantContent: "application/json"},
+		{output: "json", wantContent: "application/json"},
+		{output: "yaml", wantContent: "application/yaml"},
+		{output: "wide", wantContent: "text/plain; charset=utf-8"},
+	}
+
+	for _, c := range cases {
+		printer, err := PrinterForOutput(c.output, "")
+		if err != nil {
+			t.Fatalf("PrinterForOutput(%q) error = %v", c.output, err)
+		}
+		if got := printer.ContentType(); got != c.wantContent {
+			t.Errorf("PrinterForOutput(%q).ContentType() = %q, want %q", c.output, got, c.wantContent)
+		}
+	}
+
+	if _, err := PrinterForOutput("bogus", ""); err == nil {
+		t.Errorf("PrinterForOutput(\"bogus\") should error")
+	}
+}
+
+func TestTemplatePrinterContentType(t *testing.T) {
+	printer, err := PrinterForOutput("template", "{{len .ReplicaSets}}")
+	if err != nil {
+		t.Fatalf("PrinterForOutput(\"template\") error = %v", err)
+	}
+	if got := printer.ContentType(); got != "text/plain; charset=utf-8" {
+		t.Errorf("templatePrinter.ContentType() = %q, want %q", got, "text/plain; charset=utf-8")
+	}
+}
+
+func TestNewTemplatePrinterInvalidTemplate(t *testing.T) {
+	if _, err := PrinterForOutput("template", "{{"); err == nil {
+		t.Errorf("PrinterForOutput(\"template\") with an invalid template should error")
+	}
+}
+
+func sampleReplicaSetListResponse() *ReplicaSetListResponse {
+	return &ReplicaSetListResponse{
+		ReplicaSetList: ReplicaSetList{
+			ReplicaSets: []ReplicaSet{
+				{
+					Name:            "redis",
+					ContainerNames:  []string{"redis"},
+					ContainerImages: []string{"redis:3.2"},
+					Selector:        map[string]string{"app": "redis"},
+					Pods:            PodInfo{Desired: 3, Current: 3, Running: 2},
+				},
+			},
+		},
+	}
+}
+
+func TestJSONPrinterPrintReplicaSetList(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (jsonPrinter{}).PrintReplicaSetList(&buf, sampleReplicaSetListResponse()); err != nil {
+		t.Fatalf("PrintReplicaSetList() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"name": "redis"`) {
+		t.Errorf("jsonPrinter output = %q, want it to contain the replica set name", buf.String())
+	}
+}
+
+func TestYAMLPrinterPrintReplicaSetList(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (yamlPrinter{}).PrintReplicaSetList(&buf, sampleReplicaSetListResponse()); err != nil {
+		t.Fatalf("PrintReplicaSetList() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "name: redis") {
+		t.Errorf("yamlPrinter output = %q, want it to contain the replica set name", buf.String())
+	}
+}
+
+func TestWidePrinterPrintReplicaSetList(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (widePrinter{}).PrintReplicaSetList(&buf, sampleReplicaSetListResponse()); err != nil {
+		t.Fatalf("PrintReplicaSetList() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "NAME") || !strings.Contains(out, "redis") || !strings.Contains(out, "2/3") {
+		t.Errorf("widePrinter output = %q, want a header row and the redis row with ready count 2/3", out)
+	}
+}
+
+func TestReadySummary(t *testing.T) {
+	if got := readySummary(&PodInfo{Desired: 3, Running: 2}); got != "2/3" {
+		t.Errorf("readySummary() = %q, want %q", got, "2/3")
+	}
+}
+
+func TestLabelsToString(t *testing.T) {
+	if got := labelsToString(nil); got != "<none>" {
+		t.Errorf("labelsToString(nil) = %q, want %q", got, "<none>")
+	}
+	if got := labelsToString(map[string]string{"app": "redis"}); got != "app=redis" {
+		t.Errorf("labelsToString() = %q, want %q", got, "app=redis")
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright 2015 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/cache"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+// podLabelIndex and serviceSelectorIndex are cache.Indexers keys. Both index on
+// "namespace/key=value" pairs so a selector lookup can start from objects known to carry one of
+// the selector's pairs instead of scanning every object in the namespace.
+const (
+	podLabelIndex        = "label"
+	serviceSelectorIndex = "selector"
+)
+
+// podLabelIndexFunc emits one index key per label on the pod.
+func podLabelIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, fmt.Errorf("podLabelIndexFunc: expected *api.Pod, got %T", obj)
+	}
+	return namespacedPairs(pod.ObjectMeta.Namespace, pod.ObjectMeta.Labels), nil
+}
+
+// serviceSelectorIndexFunc emits one index key per entry in the Service's own selector, so
+// Services can be looked up by what they target rather than by their own labels.
+func serviceSelectorIndexFunc(obj interface{}) ([]string, error) {
+	service, ok := obj.(*api.Service)
+	if !ok {
+		return nil, fmt.Errorf("serviceSelectorIndexFunc: expected *api.Service, got %T", obj)
+	}
+	return namespacedPairs(service.ObjectMeta.Namespace, service.Spec.Selector), nil
+}
+
+// namespacedPairs renders each key/value pair as "namespace/key=value".
+func namespacedPairs(namespace string, pairs map[string]string) []string {
+	keys := make([]string, 0, len(pairs))
+	for key, value := range pairs {
+		keys = append(keys, namespace+"/"+key+"="+value)
+	}
+	return keys
+}
+
+// representativePair deterministically picks one key/value pair out of selector so it can be
+// used as an index lookup key. Any single pair is a valid (if partial) candidate filter: an
+// object can only fully match selector if it also matches this one pair.
+func representativePair(selector map[string]string) (string, bool) {
+	if len(selector) == 0 {
+		return "", false
+	}
+
+	keys := make([]string, 0, len(selector))
+	for key := range selector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	key := keys[0]
+	return key + "=" + selector[key], true
+}
+
+// ReplicaSetEventType describes what happened to a Replica Set row that a ReplicaSetCache
+// subscriber is being notified about.
+type ReplicaSetEventType string
+
+// Kinds of incremental changes delivered on a ReplicaSetCache subscription channel.
+const (
+	ReplicaSetAdded   ReplicaSetEventType = "ADDED"
+	ReplicaSetUpdated ReplicaSetEventType = "UPDATED"
+	ReplicaSetDeleted ReplicaSetEventType = "DELETED"
+)
+
+// ReplicaSetEvent is a single incremental change to the Replica Set list. Subscribers receive
+// these instead of having to re-fetch and re-render the whole list on every change.
+type ReplicaSetEvent struct {
+	Type       ReplicaSetEventType `json:"type"`
+	ReplicaSet ReplicaSet          `json:"replicaSet"`
+}
+
+// replicaSetSubscriberBuffer is how many pending events a subscriber channel can hold before
+// the cache starts dropping events for it rather than blocking the informer goroutines.
+const replicaSetSubscriberBuffer = 100
+
+// ReplicaSetCache is a live, incrementally updated view of Replica Sets (Replication
+// Controllers, the Services that front them and the Pods they own) kept up to date by
+// SharedIndexInformers instead of being rebuilt from three List() calls on every request. Its
+// Service and Pod indexers also carry a label-derived secondary index (see podLabelIndex and
+// serviceSelectorIndex) so that recomputing a single changed Replication Controller's row costs
+// work proportional to the Services/Pods that actually share one of its selector's labels,
+// rather than a linear scan of the namespace.
+type ReplicaSetCache struct {
+	rcController      *cache.Controller
+	rcIndexer         cache.Indexer
+	serviceController *cache.Controller
+	serviceIndexer    cache.Indexer
+	podController     *cache.Controller
+	podIndexer        cache.Indexer
+
+	mux         sync.RWMutex
+	subscribers []chan ReplicaSetEvent
+}
+
+// recoverDeletedObject unwraps the cache.DeletedFinalStateUnknown tombstone a DeleteFunc receives
+// when the delete was only observed via a watch re-list rather than a live delete event. Without
+// this, the type assertion in each DeleteFunc below would silently fail on the tombstone and the
+// corresponding ReplicaSetDeleted event would never be broadcast.
+func recoverDeletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
+
+// NewReplicaSetCache builds a ReplicaSetCache wired to the given client. Call Run to start the
+// underlying informers; until then List and Subscribe report an empty cache.
+func NewReplicaSetCache(client *client.Client) *ReplicaSetCache {
+	c := &ReplicaSetCache{}
+
+	c.rcIndexer, c.rcController = cache.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc: func(options unversioned.ListOptions) (runtime.Object, error) {
+				return client.ReplicationControllers(api.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options unversioned.ListOptions) (watch.Interface, error) {
+				return client.ReplicationControllers(api.NamespaceAll).Watch(options)
+			},
+		},
+		&api.ReplicationController{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.handleReplicaSetChange(ReplicaSetAdded, obj.(*api.ReplicationController))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.handleReplicaSetChange(ReplicaSetUpdated, newObj.(*api.ReplicationController))
+			},
+			DeleteFunc: func(obj interface{}) {
+				if rc, ok := recoverDeletedObject(obj).(*api.ReplicationController); ok {
+					c.handleReplicaSetChange(ReplicaSetDeleted, rc)
+				}
+			},
+		},
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+
+	c.serviceIndexer, c.serviceController = cache.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc: func(options unversioned.ListOptions) (runtime.Object, error) {
+				return client.Services(api.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options unversioned.ListOptions) (watch.Interface, error) {
+				return client.Services(api.NamespaceAll).Watch(options)
+			},
+		},
+		&api.Service{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.handleServiceChange(obj.(*api.Service))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldService, newService := oldObj.(*api.Service), newObj.(*api.Service)
+				c.handleServiceChange(newService)
+				// Replication Controllers the Service no longer targets lose its endpoints.
+				for _, rc := range c.rcsInNamespace(oldService.ObjectMeta.Namespace) {
+					if isLabelSelectorMatching(oldService.Spec.Selector, rc.Spec.Selector) &&
+						!isLabelSelectorMatching(newService.Spec.Selector, rc.Spec.Selector) {
+						c.handleReplicaSetChange(ReplicaSetUpdated, rc)
+					}
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				if service, ok := recoverDeletedObject(obj).(*api.Service); ok {
+					c.handleServiceChange(service)
+				}
+			},
+		},
+		cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			serviceSelectorIndex: serviceSelectorIndexFunc,
+		},
+	)
+
+	c.podIndexer, c.podController = cache.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc: func(options unversioned.ListOptions) (runtime.Object, error) {
+				return client.Pods(api.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options unversioned.ListOptions) (watch.Interface, error) {
+				return client.Pods(api.NamespaceAll).Watch(options)
+			},
+		},
+		&api.Pod{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.handlePodChange(obj.(*api.Pod))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.handlePodChange(newObj.(*api.Pod))
+			},
+			DeleteFunc: func(obj interface{}) {
+				if pod, ok := recoverDeletedObject(obj).(*api.Pod); ok {
+					c.handlePodChange(pod)
+				}
+			},
+		},
+		cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			podLabelIndex:        podLabelIndexFunc,
+		},
+	)
+
+	return c
+}
+
+// Run starts the underlying informers. It does not block; close stopCh to stop watching.
+func (c *ReplicaSetCache) Run(stopCh <-chan struct{}) {
+	log.Printf("Starting Replica Set cache")
+	go c.rcController.Run(stopCh)
+	go c.serviceController.Run(stopCh)
+	go c.podController.Run(stopCh)
+}
+
+// List returns the current Replica Set rows for the given namespace (api.NamespaceAll for all
+// namespaces), built from the indexers rather than by re-listing Replication Controllers,
+// Services and Pods on every call.
+func (c *ReplicaSetCache) List(namespace string) *ReplicaSetList {
+	var replicaSets []api.ReplicationController
+	var services []api.Service
+	var pods []api.Pod
+
+	if namespace == api.NamespaceAll {
+		for _, obj := range c.rcIndexer.List() {
+			replicaSets = append(replicaSets, *obj.(*api.ReplicationController))
+		}
+		for _, obj := range c.serviceIndexer.List() {
+			services = append(services, *obj.(*api.Service))
+		}
+		for _, obj := range c.podIndexer.List() {
+			pods = append(pods, *obj.(*api.Pod))
+		}
+	} else {
+		for _, obj := range indexByNamespace(c.rcIndexer, namespace) {
+			replicaSets = append(replicaSets, *obj.(*api.ReplicationController))
+		}
+		for _, obj := range indexByNamespace(c.serviceIndexer, namespace) {
+			services = append(services, *obj.(*api.Service))
+		}
+		for _, obj := range indexByNamespace(c.podIndexer, namespace) {
+			pods = append(pods, *obj.(*api.Pod))
+		}
+	}
+
+	return getReplicaSetList(replicaSets, services, pods)
+}
+
+// indexByNamespace looks objects up through the namespace index instead of a linear scan.
+func indexByNamespace(indexer cache.Indexer, namespace string) []interface{} {
+	objs, err := indexer.ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		log.Printf("Error looking up namespace index: %v", err)
+		return nil
+	}
+	return objs
+}
+
+// Subscribe returns a channel that receives a ReplicaSetEvent every time a watched Replica Set
+// changes, so dashboard clients can stream deltas instead of polling List.
+func (c *ReplicaSetCache) Subscribe() <-chan ReplicaSetEvent {
+	ch := make(chan ReplicaSetEvent, replicaSetSubscriberBuffer)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.subscribers = append(c.subscribers, ch)
+
+	return ch
+}
+
+// Unsubscribe stops delivering events to ch and closes it. Callers must call this once they are
+// done with a channel returned by Subscribe (e.g. when the client disconnects), otherwise the
+// cache retains the channel, and everything it references, for as long as the process runs.
+func (c *ReplicaSetCache) Unsubscribe(ch <-chan ReplicaSetEvent) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for i, sub := range c.subscribers {
+		if sub == ch {
+			c.subscribers = append(c.subscribers[:i], c.subscribers[i+1:]...)
+			close(sub)
+			return
+		}
+	}
+}
+
+// podsMatchingSelector returns the Pods in namespace that match selector. It narrows the
+// candidate set with the podLabelIndex (keyed on one representative selector pair) before
+// running the exact isLabelSelectorMatching check, instead of scanning every Pod in the
+// namespace.
+func (c *ReplicaSetCache) podsMatchingSelector(namespace string, selector map[string]string) []api.Pod {
+	pair, ok := representativePair(selector)
+	if !ok {
+		return nil
+	}
+
+	objs, err := c.podIndexer.ByIndex(podLabelIndex, namespace+"/"+pair)
+	if err != nil {
+		log.Printf("Error looking up pod label index: %v", err)
+		return nil
+	}
+
+	var pods []api.Pod
+	for _, obj := range objs {
+		pod := obj.(*api.Pod)
+		if isLabelSelectorMatching(selector, pod.ObjectMeta.Labels) {
+			pods = append(pods, *pod)
+		}
+	}
+	return pods
+}
+
+// servicesMatchingReplicaSet returns the Services in rc's namespace whose selector matches rc's
+// own selector. A Service only matches if its selector is a subset of rc's, so unlike
+// podsMatchingSelector a single representative pair of rc's selector cannot be used as the index
+// lookup key: a Service's selector may omit it entirely while still being a valid subset.
+// Instead every pair of rc's selector is looked up in the serviceSelectorIndex (a matching
+// Service is indexed under all of its own pairs, and those are necessarily a subset of rc's
+// pairs) and the results are unioned, deduplicated, before running the exact
+// isLabelSelectorMatching check. This still costs work proportional to the Services that share at
+// least one selector pair with rc, not a linear scan of the namespace.
+func (c *ReplicaSetCache) servicesMatchingReplicaSet(rc *api.ReplicationController) []api.Service {
+	var services []api.Service
+	seen := make(map[string]bool)
+
+	for _, pair := range namespacedPairs(rc.ObjectMeta.Namespace, rc.Spec.Selector) {
+		objs, err := c.serviceIndexer.ByIndex(serviceSelectorIndex, pair)
+		if err != nil {
+			log.Printf("Error looking up service selector index: %v", err)
+			continue
+		}
+
+		for _, obj := range objs {
+			service := obj.(*api.Service)
+			key := service.ObjectMeta.Namespace + "/" + service.ObjectMeta.Name
+			if seen[key] || !isLabelSelectorMatching(service.Spec.Selector, rc.Spec.Selector) {
+				continue
+			}
+			seen[key] = true
+			services = append(services, *service)
+		}
+	}
+	return services
+}
+
+// handleReplicaSetChange recomputes the ReplicaSet model for a single changed Replication
+// Controller, looking up matching Services and Pods through the label indexers above (not a
+// linear scan of the namespace) so cost stays proportional to the number of Services/Pods that
+// actually share a label with rc's selector, and broadcasts the result to subscribers.
+func (c *ReplicaSetCache) handleReplicaSetChange(eventType ReplicaSetEventType,
+	rc *api.ReplicationController) {
+
+	services := c.servicesMatchingReplicaSet(rc)
+	pods := c.podsMatchingSelector(rc.ObjectMeta.Namespace, rc.Spec.Selector)
+
+	list := getReplicaSetList([]api.ReplicationController{*rc}, services, pods)
+	if len(list.ReplicaSets) != 1 {
+		return
+	}
+
+	c.broadcast(ReplicaSetEvent{Type: eventType, ReplicaSet: list.ReplicaSets[0]})
+}
+
+// rcsInNamespace returns the Replication Controllers in namespace via the namespace index.
+// There is no reverse index from a Pod/Service's labels back to the Replication Controllers they
+// belong to, so handlePodChange/handleServiceChange scan this set; in practice it is small
+// compared to the Pods/Services in the namespace.
+func (c *ReplicaSetCache) rcsInNamespace(namespace string) []*api.ReplicationController {
+	var rcs []*api.ReplicationController
+	for _, obj := range indexByNamespace(c.rcIndexer, namespace) {
+		rcs = append(rcs, obj.(*api.ReplicationController))
+	}
+	return rcs
+}
+
+// handlePodChange recomputes and broadcasts every Replica Set whose selector matches pod's
+// labels, so a Pod's phase/readiness change (or its addition/removal) is reflected on
+// subscribers without waiting for the owning Replication Controller itself to change.
+func (c *ReplicaSetCache) handlePodChange(pod *api.Pod) {
+	for _, rc := range c.rcsInNamespace(pod.ObjectMeta.Namespace) {
+		if isLabelSelectorMatching(rc.Spec.Selector, pod.ObjectMeta.Labels) {
+			c.handleReplicaSetChange(ReplicaSetUpdated, rc)
+		}
+	}
+}
+
+// handleServiceChange recomputes and broadcasts every Replica Set that service targets, so an
+// edit to a fronting Service's selector is reflected on subscribers without waiting for the
+// owning Replication Controller itself to change.
+func (c *ReplicaSetCache) handleServiceChange(service *api.Service) {
+	for _, rc := range c.rcsInNamespace(service.ObjectMeta.Namespace) {
+		if isLabelSelectorMatching(service.Spec.Selector, rc.Spec.Selector) {
+			c.handleReplicaSetChange(ReplicaSetUpdated, rc)
+		}
+	}
+}
+
+// broadcast fans an event out to every subscriber without blocking on a slow or dead one.
+func (c *ReplicaSetCache) broadcast(event ReplicaSetEvent) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	for _, ch := range c.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Dropping Replica Set event for slow subscriber")
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/cache"
+)
+
+func TestRepresentativePair(t *testing.T) {
+	cases := []struct {
+		selector map[string]string
+		want     string
+		wantOk   bool
+	}{
+		{selector: nil, wantOk: false},
+		{selector: map[string]string{}, wantOk: false},
+		{selector: map[string]string{"app": "redis"}, want: "app=redis", wantOk: true},
+		{
+			selector: map[string]string{"tier": "backend", "app": "redis"},
+			want:     "app=redis",
+			wantOk:   true,
+		},
+	}
+
+	for _, c := range cases {
+		pair, ok := representativePair(c.selector)
+		if ok != c.wantOk || pair != c.want {
+			t.Errorf("representativePair(%v) = (%q, %v), want (%q, %v)",
+				c.selector, pair, ok, c.want, c.wantOk)
+		}
+	}
+}
+
+func TestNamespacedPairs(t *testing.T) {
+	got := namespacedPairs("kube-system", map[string]string{"app": "redis", "tier": "backend"})
+	sort.Strings(got)
+
+	want := []string{"kube-system/app=redis", "kube-system/tier=backend"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("namespacedPairs() = %v, want %v", got, want)
+	}
+}
+
+func TestPodLabelIndexFunc(t *testing.T) {
+	pod := &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Namespace: "default",
+			Labels:    map[string]string{"app": "redis"},
+		},
+	}
+
+	got, err := podLabelIndexFunc(pod)
+	if err != nil {
+		t.Fatalf("podLabelIndexFunc() error = %v", err)
+	}
+
+	want := []string{"default/app=redis"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("podLabelIndexFunc() = %v, want %v", got, want)
+	}
+
+	if _, err := podLabelIndexFunc(&api.Service{}); err == nil {
+		t.Errorf("podLabelIndexFunc() with non-Pod object should error")
+	}
+}
+
+func TestServiceSelectorIndexFunc(t *testing.T) {
+	service := &api.Service{
+		ObjectMeta: api.ObjectMeta{Namespace: "default"},
+		Spec:       api.ServiceSpec{Selector: map[string]string{"app": "redis"}},
+	}
+
+	got, err := serviceSelectorIndexFunc(service)
+	if err != nil {
+		t.Fatalf("serviceSelectorIndexFunc() error = %v", err)
+	}
+
+	want := []string{"default/app=redis"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("serviceSelectorIndexFunc() = %v, want %v", got, want)
+	}
+
+	if _, err := serviceSelectorIndexFunc(&api.Pod{}); err == nil {
+		t.Errorf("serviceSelectorIndexFunc() with non-Service object should error")
+	}
+}
+
+// TestServicesMatchingReplicaSetSubsetSelector guards against a regression where a Service
+// indexed under a selector pair the RC does not happen to pick as its "representative" pair was
+// never found. front's selector ({role: master}) omits the RC selector's alphabetically-first
+// pair (name=redis), so a lookup keyed on that single pair alone would miss it.
+func TestServicesMatchingReplicaSetSubsetSelector(t *testing.T) {
+	serviceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		serviceSelectorIndex: serviceSelectorIndexFunc,
+	})
+
+	front := &api.Service{
+		ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "front"},
+		Spec:       api.ServiceSpec{Selector: map[string]string{"role": "master"}},
+	}
+	if err := serviceIndexer.Add(front); err != nil {
+		t.Fatalf("serviceIndexer.Add() error = %v", err)
+	}
+
+	c := &ReplicaSetCache{serviceIndexer: serviceIndexer}
+	rc := &api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "redis-master"},
+		Spec: api.ReplicationControllerSpec{
+			Selector: map[string]string{"name": "redis", "role": "master"},
+		},
+	}
+
+	got := c.servicesMatchingReplicaSet(rc)
+	if len(got) != 1 || got[0].ObjectMeta.Name != "front" {
+		t.Errorf("servicesMatchingReplicaSet() = %v, want [front]", got)
+	}
+}
+
+func TestRecoverDeletedObject(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "redis-1"}}
+
+	if got := recoverDeletedObject(pod); got != interface{}(pod) {
+		t.Errorf("recoverDeletedObject(pod) = %v, want %v", got, pod)
+	}
+
+	tombstone := cache.DeletedFinalStateUnknown{Key: "default/redis-1", Obj: pod}
+	got, ok := recoverDeletedObject(tombstone).(*api.Pod)
+	if !ok || got != pod {
+		t.Errorf("recoverDeletedObject(tombstone) = %v, want %v", got, pod)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"k8s.io/kubernetes/pkg/api"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/adapters/internalclientset"
+	"k8s.io/kubernetes/pkg/kubectl"
+)
+
+// DeleteReplicaSet removes the Replication Controller with the given name. When cascade is
+// true (the default from the HTTP handler) it mirrors `kubectl delete rc`: scale to zero, wait
+// for the pods to terminate, then delete the controller itself, via kubectl.ReaperFor. A nil
+// gracePeriodSeconds leaves the pods' own default grace period in effect, the same as
+// `kubectl delete rc` with no --grace-period flag; pass a value to force a different one. When
+// cascade is false, or when no reaper is registered for Replication Controllers, it falls back
+// to a plain Delete that leaves any existing pods running.
+func DeleteReplicaSet(client *client.Client, namespace, name string, gracePeriodSeconds *int64,
+	cascade bool) error {
+
+	if !cascade {
+		return client.ReplicationControllers(namespace).Delete(name)
+	}
+
+	clientset, err := internalclientset.FromUnversionedClient(client)
+	if err != nil {
+		return err
+	}
+
+	reaper, err := kubectl.ReaperFor(api.Kind("ReplicationController"), clientset)
+	if err != nil {
+		if _, ok := err.(*kubectl.NoSuchReaperError); ok {
+			log.Printf("No reaper registered for Replication Controllers, deleting %s/%s directly",
+				namespace, name)
+			return client.ReplicationControllers(namespace).Delete(name)
+		}
+		return err
+	}
+
+	return reaper.Stop(namespace, name, 0, deleteOptionsOrDefault(gracePeriodSeconds))
+}
+
+// deleteOptionsOrDefault turns an optional gracePeriod query parameter into the *api.DeleteOptions
+// the reaper expects. A nil gracePeriod leaves the DeleteOptions nil so the reaper falls back to
+// its own default grace period, the same one `kubectl delete rc` uses, instead of forcing an
+// immediate (0s) kill of the controller's pods.
+func deleteOptionsOrDefault(gracePeriodSeconds *int64) *api.DeleteOptions {
+	if gracePeriodSeconds == nil {
+		return nil
+	}
+	return api.NewDeleteOptions(*gracePeriodSeconds)
+}
+
+// handleDeleteReplicaSet returns an http.HandlerFunc for DELETE requests against a single
+// Replica Set, parsing the `gracePeriod` and `cascade` query parameters documented on
+// DeleteReplicaSet.
+func handleDeleteReplicaSet(client *client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.URL.Query().Get("namespace")
+		name := r.URL.Query().Get("name")
+
+		cascade := true
+		if raw := r.URL.Query().Get("cascade"); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid cascade value %q: %v", raw, err), http.StatusBadRequest)
+				return
+			}
+			cascade = parsed
+		}
+
+		var gracePeriodSeconds *int64
+		if raw := r.URL.Query().Get("gracePeriod"); raw != "" {
+			seconds, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid gracePeriod value %q: %v", raw, err), http.StatusBadRequest)
+				return
+			}
+			gracePeriodSeconds = &seconds
+		}
+
+		if err := DeleteReplicaSet(client, namespace, name, gracePeriodSeconds, cascade); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestDeleteOptionsOrDefault(t *testing.T) {
+	if got := deleteOptionsOrDefault(nil); got != nil {
+		t.Errorf("deleteOptionsOrDefault(nil) = %v, want nil", got)
+	}
+
+	seconds := int64(30)
+	got := deleteOptionsOrDefault(&seconds)
+	if got == nil || got.GracePeriodSeconds == nil || *got.GracePeriodSeconds != 30 {
+		t.Errorf("deleteOptionsOrDefault(&30) = %v, want GracePeriodSeconds 30", got)
+	}
+}
@@ -15,7 +15,10 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"net/http"
+	"strconv"
 
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/unversioned"
@@ -30,6 +33,33 @@ type ReplicaSetList struct {
 	ReplicaSets []ReplicaSet `json:"replicaSets"`
 }
 
+// WorkloadKind identifies the underlying API object a ReplicaSet row was built from, since the
+// dashboard renders api.ReplicationController and extensions.ReplicaSet/Deployment through the
+// same model.
+type WorkloadKind string
+
+// Workload kinds a ReplicaSet row can represent.
+const (
+	ReplicationControllerKind WorkloadKind = "ReplicationController"
+	ReplicaSetKind            WorkloadKind = "ReplicaSet"
+	DeploymentKind            WorkloadKind = "Deployment"
+)
+
+// RollingUpdateStatus summarizes a Deployment's rollout progress. Only populated for rows
+// whose Kind is DeploymentKind.
+type RollingUpdateStatus struct {
+	// Total number of non-terminated pods targeted by this deployment that have the desired
+	// template spec.
+	UpdatedReplicas int `json:"updatedReplicas"`
+
+	// Total number of available pods (ready for at least minReadySeconds) targeted by this
+	// deployment.
+	AvailableReplicas int `json:"availableReplicas"`
+
+	// Total number of unavailable pods targeted by this deployment.
+	UnavailableReplicas int `json:"unavailableReplicas"`
+}
+
 // ReplicaSet (aka. Replication Controller) plus zero or more Kubernetes services that
 // target the Replica Set.
 type ReplicaSet struct {
@@ -45,8 +75,26 @@ type ReplicaSet struct {
 	// Label of this Replica Set.
 	Labels map[string]string `json:"labels"`
 
+	// Selector this Replica Set uses to target its pods. Omitted when the underlying selector
+	// uses matchExpressions that cannot be flattened into a plain label map.
+	Selector map[string]string `json:"selector,omitempty"`
+
+	// Kind of the workload this row was built from: ReplicationController, ReplicaSet or
+	// Deployment. Defaults to ReplicationController for rows coming from GetReplicaSetList.
+	Kind WorkloadKind `json:"kind"`
+
+	// Name of the Deployment that owns this row. Only set when Kind is ReplicaSetKind and the
+	// owning Deployment could be determined from label selectors.
+	Deployment string `json:"deployment,omitempty"`
+
+	// Rolling update replica counters. Only set when Kind is DeploymentKind.
+	RollingUpdate *RollingUpdateStatus `json:"rollingUpdate,omitempty"`
+
 	// Aggergate information about pods belonging to this repolica set.
-	Pods ReplicaSetPodInfo `json:"pods"`
+	Pods PodInfo `json:"pods"`
+
+	// Names of the containers run by the Replica Set, in the same order as ContainerImages.
+	ContainerNames []string `json:"containerNames"`
 
 	// Container images of the Replica Set.
 	ContainerImages []string `json:"containerImages"`
@@ -61,10 +109,10 @@ type ReplicaSet struct {
 	ExternalEndpoints []Endpoint `json:"externalEndpoints"`
 }
 
-// ReplicaSetPodInfo represents aggregate information about replica set pods.
-type ReplicaSetPodInfo struct {
+// PodInfo represents aggregate information about replica set pods.
+type PodInfo struct {
 	// Number of pods that are created.
-	Curret int `json:"current"`
+	Current int `json:"current"`
 
 	// Number of pods that are desired in this Replica Set.
 	Desired int `json:"desired"`
@@ -72,41 +120,234 @@ type ReplicaSetPodInfo struct {
 	// Number of pods that are currently running.
 	Running int `json:"running"`
 
-	// Number of pods that are currently waiting.
-	Waiting int `json:"waiting"`
+	// Number of pods that are currently pending.
+	Pending int `json:"pending"`
+
+	// Number of pods that have succeeded and exited.
+	Succeeded int `json:"succeeded"`
 
 	// Number of pods that are failed.
 	Failed int `json:"failed"`
+
+	// Number of pods whose status could not be determined.
+	Unknown int `json:"unknown"`
+
+	// Number of containers, summed across all pods, reporting ready.
+	Ready int `json:"ready"`
+
+	// Total container restarts, summed across all pods.
+	RestartCount int `json:"restartCount"`
+
+	// Actionable problems detected on individual pods, e.g. CrashLoopBackOff.
+	Warnings []PodWarning `json:"warnings,omitempty"`
+}
+
+// ReplicaSetPodInfo is the old name of PodInfo, kept as an alias so code still using the old
+// name compiles.
+//
+// Deprecated: use PodInfo.
+type ReplicaSetPodInfo = PodInfo
+
+// PodWarning is an actionable problem detected on a single pod, such as a container stuck in
+// CrashLoopBackOff or ImagePullBackOff, surfaced so the dashboard can show more than raw phase
+// counts.
+type PodWarning struct {
+	// Name of the pod the warning was detected on.
+	PodName string `json:"podName"`
+
+	// Reason reported by the waiting container status, e.g. "CrashLoopBackOff".
+	Reason string `json:"reason"`
+}
+
+// ReplicaSetListQuery narrows and pages through GetReplicaSetList, mirroring the filters
+// `kubectl get rc -l ...` supports.
+//
+// Limit/Continue only slice the response body after the full, unfiltered-by-page Replication
+// Controller/Service/Pod lists have already been fetched and built into a ReplicaSetList; they
+// are not real apiserver chunked listing (unversioned.ListOptions in this client has no
+// limit/continue fields to push that work down to the apiserver). This keeps the wire response
+// small but does not reduce the List() cost behind GetReplicaSetList, and Continue is a plain
+// slice offset rather than a resourceVersion-pinned apiserver token, so a page fetched this way
+// is not guaranteed stable if Replica Sets are added or removed between requests.
+type ReplicaSetListQuery struct {
+	// Namespace restricts the list to a single namespace. Empty means api.NamespaceAll.
+	Namespace string
+
+	// LabelSelector is parsed with labels.Parse, e.g. "k8s-app=heapster,tier in (frontend,backend)".
+	LabelSelector string
+
+	// FieldSelector is parsed with fields.ParseSelector.
+	FieldSelector string
+
+	// Limit caps the number of Replica Sets returned in the response body. Zero means no limit.
+	Limit int
+
+	// Continue resumes a previous query at the response-slice offset encoded in its Continue
+	// token. Not a resourceVersion-pinned apiserver continue token; see the type doc comment.
+	Continue string
 }
 
-// GetReplicaSetList returns a list of all Replica Sets in the cluster.
-func GetReplicaSetList(client *client.Client) (*ReplicaSetList, error) {
-	log.Printf("Getting list of all replica sets in the cluster")
+// ReplicaSetListResponse is a single page of a Replica Set list, produced by slicing an
+// already-fetched ReplicaSetList rather than by chunked apiserver listing; see
+// ReplicaSetListQuery.
+type ReplicaSetListResponse struct {
+	ReplicaSetList
 
+	// Continue is an opaque offset to pass as ReplicaSetListQuery.Continue to fetch the next
+	// slice of the response. Empty once the last page has been returned.
+	Continue string `json:"continue,omitempty"`
+}
+
+// GetReplicaSetList returns the Replica Sets matching query, a single page at a time. Limit and
+// Continue only trim the response body (see ReplicaSetListQuery); this still issues a full,
+// unpaged List() for Replication Controllers, Services and Pods, so it does not reduce apiserver
+// or network load on large clusters.
+func GetReplicaSetList(client *client.Client, query *ReplicaSetListQuery) (*ReplicaSetListResponse, error) {
+	log.Printf("Getting list of replica sets matching %+v", query)
+
+	labelSelector, err := labels.Parse(query.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldSelector, err := fields.ParseSelector(query.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	rcListOptions := unversioned.ListOptions{
+		LabelSelector: unversioned.LabelSelector{labelSelector},
+		FieldSelector: unversioned.FieldSelector{fieldSelector},
+	}
+
+	// query.LabelSelector/FieldSelector narrow which Replication Controllers the caller wants
+	// back. Services and Pods must still be fetched unfiltered: their own labels have nothing
+	// to do with the query string, and filtering them by it would make getMatchingServices and
+	// getReplicaSetPodInfo silently drop Services/Pods that are legitimately owned by a
+	// matching Replication Controller just because they don't themselves carry that label.
 	listEverything := unversioned.ListOptions{
 		LabelSelector: unversioned.LabelSelector{labels.Everything()},
 		FieldSelector: unversioned.FieldSelector{fields.Everything()},
 	}
 
-	replicaSets, err := client.ReplicationControllers(api.NamespaceAll).List(listEverything)
+	namespace := query.Namespace
+	if namespace == "" {
+		namespace = api.NamespaceAll
+	}
+
+	replicaSets, err := client.ReplicationControllers(namespace).List(rcListOptions)
 
 	if err != nil {
 		return nil, err
 	}
 
-	services, err := client.Services(api.NamespaceAll).List(listEverything)
+	services, err := client.Services(namespace).List(listEverything)
 
 	if err != nil {
 		return nil, err
 	}
 
-	pods, err := client.Pods(api.NamespaceAll).List(listEverything)
+	pods, err := client.Pods(namespace).List(listEverything)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return getReplicaSetList(replicaSets.Items, services.Items, pods.Items), nil
+	list := getReplicaSetList(replicaSets.Items, services.Items, pods.Items)
+	return paginateReplicaSetList(list, query.Limit, query.Continue)
+}
+
+// paginateReplicaSetList slices an already-fully-fetched list into a page of at most limit
+// Replica Sets, starting after the offset encoded in continueToken. A zero limit returns the
+// whole list. This only trims the response body: list has already paid the full List() cost
+// for every Replica Set, so it is response-body slicing, not apiserver-side pagination.
+func paginateReplicaSetList(list *ReplicaSetList, limit int, continueToken string) (*ReplicaSetListResponse, error) {
+	start := 0
+	if continueToken != "" {
+		offset, err := strconv.Atoi(continueToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid continue token %q: %v", continueToken, err)
+		}
+		start = offset
+	}
+
+	if start > len(list.ReplicaSets) {
+		start = len(list.ReplicaSets)
+	}
+
+	end := len(list.ReplicaSets)
+	nextContinue := ""
+	if limit > 0 && start+limit < end {
+		end = start + limit
+		nextContinue = strconv.Itoa(end)
+	}
+
+	return &ReplicaSetListResponse{
+		ReplicaSetList: ReplicaSetList{ReplicaSets: list.ReplicaSets[start:end]},
+		Continue:       nextContinue,
+	}, nil
+}
+
+// handleGetReplicaSetList returns an http.HandlerFunc for GET requests against the Replica Set
+// list, reading the labelSelector, namespace, fieldSelector, limit and continue query
+// parameters into a ReplicaSetListQuery. limit/continue only trim the response body — they do
+// not bound the underlying List() calls, so they shrink what goes over the wire but not the
+// load a large cluster puts on the apiserver; see ReplicaSetListQuery and GetReplicaSetList.
+func handleGetReplicaSetList(client *client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := &ReplicaSetListQuery{
+			Namespace:     r.URL.Query().Get("namespace"),
+			LabelSelector: r.URL.Query().Get("labelSelector"),
+			FieldSelector: r.URL.Query().Get("fieldSelector"),
+			Continue:      r.URL.Query().Get("continue"),
+		}
+
+		if _, err := labels.Parse(query.LabelSelector); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid labelSelector value %q: %v", query.LabelSelector, err),
+				http.StatusBadRequest)
+			return
+		}
+
+		if _, err := fields.ParseSelector(query.FieldSelector); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid fieldSelector value %q: %v", query.FieldSelector, err),
+				http.StatusBadRequest)
+			return
+		}
+
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			limit, err := strconv.Atoi(raw)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid limit value %q: %v", raw, err), http.StatusBadRequest)
+				return
+			}
+			query.Limit = limit
+		}
+
+		if query.Continue != "" {
+			if _, err := strconv.Atoi(query.Continue); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid continue value %q: %v", query.Continue, err),
+					http.StatusBadRequest)
+				return
+			}
+		}
+
+		printer, err := PrinterForOutput(r.URL.Query().Get("output"), r.URL.Query().Get("template"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		list, err := GetReplicaSetList(client, query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", printer.ContentType())
+		if err := printer.PrintReplicaSetList(w, list); err != nil {
+			log.Printf("Error writing replica set list response: %v", err)
+		}
+	}
 }
 
 // Returns a list of all Replica Set model objects in the cluster, based on all Kubernetes
@@ -118,8 +359,10 @@ func getReplicaSetList(replicaSets []api.ReplicationController, services []api.S
 	replicaSetList := &ReplicaSetList{ReplicaSets: make([]ReplicaSet, 0)}
 
 	for _, replicaSet := range replicaSets {
+		var containerNames []string
 		var containerImages []string
 		for _, container := range replicaSet.Spec.Template.Spec.Containers {
+			containerNames = append(containerNames, container.Name)
 			containerImages = append(containerImages, container.Image)
 		}
 
@@ -142,7 +385,10 @@ func getReplicaSetList(replicaSets []api.ReplicationController, services []api.S
 			Namespace:         replicaSet.ObjectMeta.Namespace,
 			Description:       replicaSet.Annotations[DescriptionAnnotationKey],
 			Labels:            replicaSet.ObjectMeta.Labels,
+			Selector:          replicaSet.Spec.Selector,
+			Kind:              ReplicationControllerKind,
 			Pods:              podInfo,
+			ContainerNames:    containerNames,
 			ContainerImages:   containerImages,
 			CreationTime:      replicaSet.ObjectMeta.CreationTimestamp,
 			InternalEndpoints: internalEndpoints,
@@ -153,29 +399,65 @@ func getReplicaSetList(replicaSets []api.ReplicationController, services []api.S
 	return replicaSetList
 }
 
-func getReplicaSetPodInfo(replicaSet *api.ReplicationController, pods []api.Pod) ReplicaSetPodInfo {
-	result := ReplicaSetPodInfo{
-		Curret:  replicaSet.Status.Replicas,
+func getReplicaSetPodInfo(replicaSet *api.ReplicationController, pods []api.Pod) PodInfo {
+	result := PodInfo{
+		Current: replicaSet.Status.Replicas,
 		Desired: replicaSet.Spec.Replicas,
 	}
 
 	for _, pod := range pods {
 		if pod.ObjectMeta.Namespace == replicaSet.ObjectMeta.Namespace &&
 			isLabelSelectorMatching(replicaSet.Spec.Selector, pod.ObjectMeta.Labels) {
-			switch pod.Status.Phase {
-			case api.PodRunning:
-				result.Running++
-			case api.PodPending:
-				result.Waiting++
-			case api.PodFailed:
-				result.Failed++
-			}
+			updatePodInfoForPhase(&result, &pod)
+			updatePodInfoForContainers(&result, &pod)
 		}
 	}
 
 	return result
 }
 
+// updatePodInfoForPhase increments the PodInfo counter matching the given pod's phase. Shared
+// by every workload kind (Replication Controller, ReplicaSet, Deployment) so they all count
+// pods the same way.
+func updatePodInfoForPhase(podInfo *PodInfo, pod *api.Pod) {
+	switch pod.Status.Phase {
+	case api.PodRunning:
+		podInfo.Running++
+	case api.PodPending:
+		podInfo.Pending++
+	case api.PodSucceeded:
+		podInfo.Succeeded++
+	case api.PodFailed:
+		podInfo.Failed++
+	case api.PodUnknown:
+		podInfo.Unknown++
+	}
+}
+
+// crashingContainerReasons are the waiting-container reasons worth surfacing as a PodWarning
+// rather than just a phase count.
+var crashingContainerReasons = map[string]bool{
+	"CrashLoopBackOff": true,
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+}
+
+// updatePodInfoForContainers sums per-container readiness and restart counts into podInfo, and
+// records a PodWarning for any container stuck waiting on a known bad reason.
+func updatePodInfoForContainers(podInfo *PodInfo, pod *api.Pod) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Ready {
+			podInfo.Ready++
+		}
+		podInfo.RestartCount += containerStatus.RestartCount
+
+		if waiting := containerStatus.State.Waiting; waiting != nil && crashingContainerReasons[waiting.Reason] {
+			podInfo.Warnings = append(podInfo.Warnings,
+				PodWarning{PodName: pod.ObjectMeta.Name, Reason: waiting.Reason})
+		}
+	}
+}
+
 // Returns all services that target the same Pods (or subset) as the given Replica Set.
 func getMatchingServices(services []api.Service,
 	replicaSet *api.ReplicationController) []api.Service {
@@ -207,3 +489,22 @@ func isLabelSelectorMatching(labelSelector map[string]string,
 	}
 	return true
 }
+
+// isObjectSelectorMatching returns true when testedObjectLabels satisfy labelSelector. Unlike
+// isLabelSelectorMatching, labelSelector here is an unversioned.LabelSelector as used by
+// Deployments and ReplicaSets, which supports matchExpressions in addition to matchLabels.
+func isObjectSelectorMatching(labelSelector *unversioned.LabelSelector,
+	testedObjectLabels map[string]string) bool {
+
+	if labelSelector == nil {
+		return false
+	}
+
+	selector, err := unversioned.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		log.Printf("Error converting label selector %v: %v", labelSelector, err)
+		return false
+	}
+
+	return selector.Matches(labels.Set(testedObjectLabels))
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func threeReplicaSets() *ReplicaSetList {
+	return &ReplicaSetList{
+		ReplicaSets: []ReplicaSet{
+			{Name: "a"},
+			{Name: "b"},
+			{Name: "c"},
+		},
+	}
+}
+
+func TestPaginateReplicaSetListNoLimit(t *testing.T) {
+	response, err := paginateReplicaSetList(threeReplicaSets(), 0, "")
+	if err != nil {
+		t.Fatalf("paginateReplicaSetList() error = %v", err)
+	}
+
+	if len(response.ReplicaSets) != 3 {
+		t.Errorf("len(response.ReplicaSets) = %d, want 3", len(response.ReplicaSets))
+	}
+	if response.Continue != "" {
+		t.Errorf("response.Continue = %q, want empty when everything fit on one page", response.Continue)
+	}
+}
+
+func TestPaginateReplicaSetListWithLimit(t *testing.T) {
+	response, err := paginateReplicaSetList(threeReplicaSets(), 2, "")
+	if err != nil {
+		t.Fatalf("paginateReplicaSetList() error = %v", err)
+	}
+
+	if len(response.ReplicaSets) != 2 {
+		t.Errorf("len(response.ReplicaSets) = %d, want 2", len(response.ReplicaSets))
+	}
+	if response.ReplicaSets[0].Name != "a" || response.ReplicaSets[1].Name != "b" {
+		t.Errorf("response.ReplicaSets = %v, want [a b]", response.ReplicaSets)
+	}
+	if response.Continue != "2" {
+		t.Errorf("response.Continue = %q, want %q", response.Continue, "2")
+	}
+}
+
+func TestPaginateReplicaSetListResumesAtContinueToken(t *testing.T) {
+	response, err := paginateReplicaSetList(threeReplicaSets(), 2, "2")
+	if err != nil {
+		t.Fatalf("paginateReplicaSetList() error = %v", err)
+	}
+
+	if len(response.ReplicaSets) != 1 || response.ReplicaSets[0].Name != "c" {
+		t.Errorf("response.ReplicaSets = %v, want [c]", response.ReplicaSets)
+	}
+	if response.Continue != "" {
+		t.Errorf("response.Continue = %q, want empty on the last page", response.Continue)
+	}
+}
+
+func TestPaginateReplicaSetListInvalidContinueToken(t *testing.T) {
+	if _, err := paginateReplicaSetList(threeReplicaSets(), 0, "not-a-number"); err == nil {
+		t.Errorf("paginateReplicaSetList() with an invalid continue token should error")
+	}
+}
+
+func TestPaginateReplicaSetListContinueTokenPastEnd(t *testing.T) {
+	response, err := paginateReplicaSetList(threeReplicaSets(), 0, "10")
+	if err != nil {
+		t.Fatalf("paginateReplicaSetList() error = %v", err)
+	}
+
+	if len(response.ReplicaSets) != 0 {
+		t.Errorf("len(response.ReplicaSets) = %d, want 0 when the continue token is past the end", len(response.ReplicaSets))
+	}
+}
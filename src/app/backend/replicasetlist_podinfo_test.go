@@ -0,0 +1,91 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api"
+)
+
+func TestUpdatePodInfoForPhase(t *testing.T) {
+	cases := []struct {
+		phase api.PodPhase
+		check func(PodInfo) int
+	}{
+		{api.PodRunning, func(p PodInfo) int { return p.Running }},
+		{api.PodPending, func(p PodInfo) int { return p.Pending }},
+		{api.PodSucceeded, func(p PodInfo) int { return p.Succeeded }},
+		{api.PodFailed, func(p PodInfo) int { return p.Failed }},
+		{api.PodUnknown, func(p PodInfo) int { return p.Unknown }},
+	}
+
+	for _, c := range cases {
+		var podInfo PodInfo
+		updatePodInfoForPhase(&podInfo, &api.Pod{Status: api.PodStatus{Phase: c.phase}})
+		if got := c.check(podInfo); got != 1 {
+			t.Errorf("updatePodInfoForPhase() for phase %v did not increment the matching counter", c.phase)
+		}
+	}
+}
+
+func TestUpdatePodInfoForContainers(t *testing.T) {
+	pod := &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "redis-abcde"},
+		Status: api.PodStatus{
+			ContainerStatuses: []api.ContainerStatus{
+				{Ready: true, RestartCount: 2},
+				{
+					Ready:        false,
+					RestartCount: 5,
+					State: api.ContainerState{
+						Waiting: &api.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+				},
+			},
+		},
+	}
+
+	var podInfo PodInfo
+	updatePodInfoForContainers(&podInfo, pod)
+
+	if podInfo.Ready != 1 {
+		t.Errorf("podInfo.Ready = %d, want 1", podInfo.Ready)
+	}
+	if podInfo.RestartCount != 7 {
+		t.Errorf("podInfo.RestartCount = %d, want 7", podInfo.RestartCount)
+	}
+	if len(podInfo.Warnings) != 1 || podInfo.Warnings[0].Reason != "CrashLoopBackOff" ||
+		podInfo.Warnings[0].PodName != "redis-abcde" {
+		t.Errorf("podInfo.Warnings = %v, want a single CrashLoopBackOff warning for redis-abcde", podInfo.Warnings)
+	}
+}
+
+func TestUpdatePodInfoForContainersIgnoresBenignWaitingReasons(t *testing.T) {
+	pod := &api.Pod{
+		Status: api.PodStatus{
+			ContainerStatuses: []api.ContainerStatus{
+				{State: api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+			},
+		},
+	}
+
+	var podInfo PodInfo
+	updatePodInfoForContainers(&podInfo, pod)
+
+	if len(podInfo.Warnings) != 0 {
+		t.Errorf("podInfo.Warnings = %v, want none for a benign waiting reason", podInfo.Warnings)
+	}
+}